@@ -0,0 +1,309 @@
+// Package core implements the low-level HTTP transport shared by every
+// package in this module. It used to live upstream in
+// github.com/matm/go-nowpayments; we now own it directly so it can grow
+// context support, retries, and other transport-level concerns without
+// waiting on an external fork.
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+const (
+	// DefaultBaseURL is the production NOWPayments API host used when no
+	// option.WithBaseURL is supplied.
+	DefaultBaseURL = "https://api.nowpayments.io"
+
+	// DefaultRequestTimeout bounds a single HTTP round trip (all retry
+	// attempts included) when no option.WithRequestTimeout is supplied.
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+// RouteName identifies a logical NOWPayments API operation. HTTPSend
+// resolves it to an HTTP method and path template.
+type RouteName string
+
+// Known routes. PathArgs on SendParams are fmt.Sprintf'd into Path in
+// order, so routes with %s placeholders require matching PathArgs.
+const (
+	RoutePaymentCreate  RouteName = "payment-create"
+	RouteInvoicePayment RouteName = "invoice-payment"
+	RoutePaymentList    RouteName = "payment-list"
+	RoutePaymentStatus  RouteName = "payment-status"
+)
+
+type route struct {
+	Method string
+	Path   string
+}
+
+var routes = map[RouteName]route{
+	RoutePaymentCreate:  {http.MethodPost, "/v1/payment"},
+	RouteInvoicePayment: {http.MethodPost, "/v1/invoice-payment"},
+	RoutePaymentList:    {http.MethodGet, "/v1/payment/"},
+	RoutePaymentStatus:  {http.MethodGet, "/v1/payment/%s"},
+}
+
+// SendParams describes a single API call.
+type SendParams struct {
+	// RouteName selects the method and path, see routes.
+	RouteName RouteName
+	// Into receives the decoded JSON response body.
+	Into interface{}
+	// Body is the JSON-encoded request body, if any.
+	Body io.Reader
+	// PathArgs are substituted into the route's path template.
+	PathArgs []interface{}
+	// Query is encoded as the request's URL query string.
+	Query map[string]string
+}
+
+// RequestConfig is the per-call configuration assembled from Option
+// values. Callers never build one directly; use the option package.
+type RequestConfig struct {
+	HTTPClient     *http.Client
+	BaseURL        string
+	APIKey         string
+	IdempotencyKey string
+	RequestTimeout time.Duration
+	Headers        http.Header
+	RetryPolicy    RetryPolicy
+}
+
+// RetryPolicy controls how HTTPSend retries a failed call.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	// A value <= 1 disables retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between retries.
+	MaxDelay time.Duration
+	// RetryableStatuses are the HTTP status codes that trigger a retry.
+	RetryableStatuses []int
+}
+
+// DefaultRetryPolicy retries 429 and 5xx responses up to 4 times total,
+// backing off exponentially from 500ms and capping at 30s.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:       4,
+	BaseDelay:         500 * time.Millisecond,
+	MaxDelay:          30 * time.Second,
+	RetryableStatuses: defaultRetryableStatuses(),
+}
+
+// defaultRetryableStatuses returns 429 followed by every 5xx status code.
+func defaultRetryableStatuses() []int {
+	s := []int{http.StatusTooManyRequests}
+	for code := 500; code <= 599; code++ {
+		s = append(s, code)
+	}
+	return s
+}
+
+func (p RetryPolicy) isRetryable(status int) bool {
+	for _, s := range p.RetryableStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before retry attempt n (1-indexed), honoring
+// retryAfter when the server provided one, otherwise exponential backoff
+// with full jitter.
+func (p RetryPolicy) backoff(n int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	d := p.BaseDelay * time.Duration(1<<uint(n-1))
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// Option configures a RequestConfig. Options are applied in the order
+// they're passed to HTTPSend, so a later option overrides an earlier one.
+type Option func(*RequestConfig)
+
+func newRequestConfig() *RequestConfig {
+	return &RequestConfig{
+		HTTPClient:     http.DefaultClient,
+		BaseURL:        DefaultBaseURL,
+		RequestTimeout: DefaultRequestTimeout,
+		Headers:        make(http.Header),
+		RetryPolicy:    DefaultRetryPolicy,
+	}
+}
+
+// HTTPSend performs the HTTP round trip described by par, applying opts,
+// and decodes the JSON response into par.Into. ctx governs cancellation
+// and deadlines for the whole call, including any retries.
+func HTTPSend(ctx context.Context, par *SendParams, opts ...Option) error {
+	if par == nil {
+		return eris.New("nil send params")
+	}
+	rt, ok := routes[par.RouteName]
+	if !ok {
+		return eris.Errorf("unknown route %q", par.RouteName)
+	}
+
+	cfg := newRequestConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	path := rt.Path
+	if len(par.PathArgs) > 0 {
+		path = fmt.Sprintf(path, par.PathArgs...)
+	}
+	if len(par.Query) > 0 {
+		q := url.Values{}
+		for k, v := range par.Query {
+			q.Set(k, v)
+		}
+		path += "?" + q.Encode()
+	}
+
+	// Buffer the body once so it can be replayed across retry attempts;
+	// the same Idempotency-Key header is sent every time, so the server
+	// can safely dedupe.
+	var body []byte
+	if par.Body != nil {
+		var err error
+		body, err = io.ReadAll(par.Body)
+		if err != nil {
+			return eris.Wrap(err, "reading request body")
+		}
+	}
+
+	maxAttempts := cfg.RetryPolicy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		resp, err := doOnce(ctx, cfg, rt.Method, cfg.BaseURL+path, body)
+		if err != nil {
+			lastErr = eris.Wrap(err, "sending request")
+		} else {
+			lastErr = readResponse(resp, rt.Method, path, par.Into)
+		}
+
+		status, retryable := retryableStatus(lastErr)
+		if lastErr == nil || attempt == maxAttempts || !(retryable && cfg.RetryPolicy.isRetryable(status)) {
+			return lastErr
+		}
+
+		delay := cfg.RetryPolicy.backoff(attempt, retryAfterFrom(lastErr))
+		select {
+		case <-ctx.Done():
+			return lastErr
+		case <-time.After(delay):
+		}
+	}
+	return lastErr
+}
+
+func doOnce(ctx context.Context, cfg *RequestConfig, method, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, eris.Wrap(err, "building request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.APIKey != "" {
+		req.Header.Set("x-api-key", cfg.APIKey)
+	}
+	if cfg.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", cfg.IdempotencyKey)
+	}
+	for k, vs := range cfg.Headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	return cfg.HTTPClient.Do(req)
+}
+
+// httpStatusError carries the response status and Retry-After so the
+// retry loop can decide whether and how long to wait, without re-parsing
+// the error string.
+type httpStatusError struct {
+	status     int
+	retryAfter time.Duration
+	err        error
+}
+
+func (e *httpStatusError) Error() string { return e.err.Error() }
+func (e *httpStatusError) Unwrap() error { return e.err }
+
+func readResponse(resp *http.Response, method, path string, into interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &httpStatusError{
+			status:     resp.StatusCode,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			err:        eris.Errorf("nowpayments: %s %s: status %d: %s", method, path, resp.StatusCode, respBody),
+		}
+	}
+
+	if into == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+		return eris.Wrap(err, "decoding response")
+	}
+	return nil
+}
+
+func retryableStatus(err error) (status int, ok bool) {
+	var statusErr *httpStatusError
+	if err == nil {
+		return 0, false
+	}
+	if errors.As(err, &statusErr) {
+		return statusErr.status, true
+	}
+	return 0, false
+}
+
+func retryAfterFrom(err error) time.Duration {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.retryAfter
+	}
+	return 0
+}
+
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPSendRetriesRetryableStatus(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	par := &SendParams{RouteName: RoutePaymentStatus, PathArgs: []interface{}{"123"}}
+	err := HTTPSend(context.Background(), par,
+		func(cfg *RequestConfig) { cfg.BaseURL = srv.URL },
+		func(cfg *RequestConfig) {
+			cfg.RetryPolicy = RetryPolicy{
+				MaxAttempts:       5,
+				BaseDelay:         time.Millisecond,
+				MaxDelay:          2 * time.Millisecond,
+				RetryableStatuses: []int{http.StatusServiceUnavailable},
+			}
+		})
+	if err != nil {
+		t.Fatalf("HTTPSend: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestHTTPSendStopsAtMaxAttempts(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	par := &SendParams{RouteName: RoutePaymentStatus, PathArgs: []interface{}{"123"}}
+	err := HTTPSend(context.Background(), par,
+		func(cfg *RequestConfig) { cfg.BaseURL = srv.URL },
+		func(cfg *RequestConfig) {
+			cfg.RetryPolicy = RetryPolicy{
+				MaxAttempts:       3,
+				BaseDelay:         time.Millisecond,
+				MaxDelay:          2 * time.Millisecond,
+				RetryableStatuses: []int{http.StatusServiceUnavailable},
+			}
+		})
+	if err == nil {
+		t.Fatal("HTTPSend: want error after exhausting retries, got nil")
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3 (MaxAttempts)", calls)
+	}
+}
+
+func TestHTTPSendDoesNotRetryNonRetryableStatus(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	par := &SendParams{RouteName: RoutePaymentStatus, PathArgs: []interface{}{"123"}}
+	err := HTTPSend(context.Background(), par, func(cfg *RequestConfig) { cfg.BaseURL = srv.URL })
+	if err == nil {
+		t.Fatal("HTTPSend: want error for 400, got nil")
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 (400 is not retryable)", calls)
+	}
+}
+
+func TestHTTPSendHonorsContextCancellationBetweenRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	par := &SendParams{RouteName: RoutePaymentStatus, PathArgs: []interface{}{"123"}}
+	err := HTTPSend(ctx, par,
+		func(cfg *RequestConfig) { cfg.BaseURL = srv.URL },
+		func(cfg *RequestConfig) {
+			cfg.RetryPolicy = RetryPolicy{
+				MaxAttempts:       10,
+				BaseDelay:         time.Hour,
+				MaxDelay:          time.Hour,
+				RetryableStatuses: []int{http.StatusServiceUnavailable},
+			}
+		})
+	if err == nil {
+		t.Fatal("HTTPSend: want error from context cancellation, got nil")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	cases := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"not-a-duration", 0},
+	}
+	for _, c := range cases {
+		got := parseRetryAfter(c.in)
+		if got != c.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffHonorsRetryAfter(t *testing.T) {
+	p := DefaultRetryPolicy
+	got := p.backoff(1, 7*time.Second)
+	if got != 7*time.Second {
+		t.Fatalf("backoff with retryAfter = %v, want %v", got, 7*time.Second)
+	}
+}
+
+func TestRetryPolicyBackoffCapsAtMaxDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 3 * time.Second}
+	for n := 1; n <= 10; n++ {
+		if d := p.backoff(n, 0); d > p.MaxDelay {
+			t.Fatalf("backoff(%d, 0) = %v, want <= %v", n, d, p.MaxDelay)
+		}
+	}
+}
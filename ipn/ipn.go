@@ -0,0 +1,175 @@
+// Package ipn verifies and dispatches NOWPayments IPN (Instant Payment
+// Notification) webhook callbacks.
+package ipn
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/rotisserie/eris"
+	"github.com/topkekega/go-nowpayments/payments"
+)
+
+// SignatureHeader is the HTTP header NOWPayments sets with the
+// HMAC-SHA512 signature of the callback body.
+const SignatureHeader = "x-nowpayments-sig"
+
+// Verify checks that signature is the hex-encoded HMAC-SHA512 of body,
+// computed the way NOWPayments does it: over the JSON body with its
+// object keys sorted lexicographically at every nesting level, using
+// secret as the HMAC key.
+func Verify(body []byte, signature string, secret string) error {
+	canonical, err := canonicalize(body)
+	if err != nil {
+		return eris.Wrap(err, "canonicalizing ipn body")
+	}
+	mac := hmac.New(sha512.New, []byte(secret))
+	mac.Write(canonical)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("ipn: signature mismatch")
+	}
+	return nil
+}
+
+// canonicalize sorts body's object keys lexicographically at every
+// nesting level, the way NOWPayments signs callbacks. Numbers keep their
+// original token bytes; keys and strings are re-encoded without HTML
+// escaping, so characters such as & and < are written as themselves.
+// Numbers must survive untouched: round-tripping through interface{}/json.Marshal would
+// reformat "10.00" as "10" and lose precision on payment_id values above
+// 2^53, producing a canonical form NOWPayments never actually signed.
+func canonicalize(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := encodeCanonical(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeCanonical writes v to buf as JSON, sorting object keys
+// lexicographically at every level and passing json.Number values
+// through verbatim instead of via float64.
+func encodeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			kb, err := marshalNoEscape(k)
+			if err != nil {
+				return err
+			}
+			buf.Write(kb)
+			buf.WriteByte(':')
+			if err := encodeCanonical(buf, t[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeCanonical(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case json.Number:
+		buf.WriteString(t.String())
+	default:
+		b, err := marshalNoEscape(t)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return nil
+}
+
+// marshalNoEscape is json.Marshal without HTML escaping, which would
+// otherwise rewrite &, < and > inside strings as \u escapes.
+func marshalNoEscape(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// Handler verifies NOWPayments IPN callbacks and dispatches each one to
+// the callback registered for its payment_status via On, so downstream
+// code gets a typed event stream instead of raw JSON.
+type Handler struct {
+	// Secret is the IPN secret configured in the NOWPayments dashboard.
+	Secret string
+
+	handlers map[payments.Status]func(*payments.Payment)
+}
+
+// NewHandler returns a Handler that verifies callbacks against secret.
+func NewHandler(secret string) *Handler {
+	return &Handler{
+		Secret:   secret,
+		handlers: make(map[payments.Status]func(*payments.Payment)),
+	}
+}
+
+// On registers fn to run whenever a verified callback reports status.
+// Registering a second handler for the same status replaces the first.
+func (h *Handler) On(status payments.Status, fn func(*payments.Payment)) {
+	h.handlers[status] = fn
+}
+
+// ServeHTTP implements http.Handler. It verifies the request's
+// x-nowpayments-sig header against Secret, decodes the body into a
+// payments.Payment, and dispatches it to the handler registered for its
+// status, if any. Unverified or undecodable callbacks are rejected
+// before any handler runs.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "reading body", http.StatusBadRequest)
+		return
+	}
+	if err := Verify(body, r.Header.Get(SignatureHeader), h.Secret); err != nil {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var p payments.Payment
+	if err := json.Unmarshal(body, &p); err != nil {
+		http.Error(w, "decoding payment", http.StatusBadRequest)
+		return
+	}
+
+	if fn, ok := h.handlers[p.Status]; ok {
+		fn(&p)
+	}
+	w.WriteHeader(http.StatusOK)
+}
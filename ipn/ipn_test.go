@@ -0,0 +1,58 @@
+package ipn
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+)
+
+func sign(t *testing.T, canonical []byte, secret string) string {
+	t.Helper()
+	mac := hmac.New(sha512.New, []byte(secret))
+	mac.Write(canonical)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "shh"
+	body := []byte(`{"b":2,"a":1}`)
+	canonical := []byte(`{"a":1,"b":2}`)
+	sig := sign(t, canonical, secret)
+
+	if err := Verify(body, sig, secret); err != nil {
+		t.Fatalf("Verify with correct signature: %v", err)
+	}
+	if err := Verify(body, sig, "wrong"); err == nil {
+		t.Fatal("Verify with wrong secret: want error, got nil")
+	}
+	if err := Verify(body, "deadbeef", secret); err == nil {
+		t.Fatal("Verify with wrong signature: want error, got nil")
+	}
+}
+
+func TestVerifyPreservesNumberFormatting(t *testing.T) {
+	// Trailing zeros and large integer IDs must survive canonicalization
+	// byte-for-byte; a float64 round trip would reformat 10.00 as 10 and
+	// round 123456789012345678 to 123456789012345680, breaking every
+	// genuine callback containing such values.
+	const secret = "shh"
+	body := []byte(`{"price_amount":10.00,"payment_id":123456789012345678}`)
+	canonical := []byte(`{"payment_id":123456789012345678,"price_amount":10.00}`)
+	sig := sign(t, canonical, secret)
+
+	if err := Verify(body, sig, secret); err != nil {
+		t.Fatalf("Verify with preserved number formatting: %v", err)
+	}
+}
+
+func TestCanonicalizeSortsNestedKeys(t *testing.T) {
+	got, err := canonicalize([]byte(`{"z":1,"a":{"y":2,"x":3},"m":[{"b":1,"a":2}]}`))
+	if err != nil {
+		t.Fatalf("canonicalize: %v", err)
+	}
+	want := `{"a":{"x":3,"y":2},"m":[{"a":2,"b":1}],"z":1}`
+	if string(got) != want {
+		t.Fatalf("canonicalize() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+// Package option provides the functional options accepted by the
+// context-aware calls in the payments package. It is a thin, stable
+// public surface over core.RequestConfig so callers never need to import
+// the core package directly.
+package option
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/topkekega/go-nowpayments/core"
+)
+
+// RequestOption configures a single API call. See the With* functions
+// below for the available settings.
+type RequestOption = core.Option
+
+// WithHTTPClient overrides the *http.Client used to perform the request.
+func WithHTTPClient(c *http.Client) RequestOption {
+	return func(cfg *core.RequestConfig) {
+		cfg.HTTPClient = c
+	}
+}
+
+// WithBaseURL overrides the NOWPayments API host, e.g. to target the
+// sandbox environment.
+func WithBaseURL(baseURL string) RequestOption {
+	return func(cfg *core.RequestConfig) {
+		cfg.BaseURL = baseURL
+	}
+}
+
+// WithAPIKey sets the x-api-key header sent with the request.
+func WithAPIKey(apiKey string) RequestOption {
+	return func(cfg *core.RequestConfig) {
+		cfg.APIKey = apiKey
+	}
+}
+
+// WithIdempotencyKey sets the Idempotency-Key header sent with the
+// request, so retried or duplicated calls are deduplicated server-side.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(cfg *core.RequestConfig) {
+		cfg.IdempotencyKey = key
+	}
+}
+
+// WithRequestTimeout bounds how long the call, including any retries,
+// is allowed to take before its context is cancelled.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(cfg *core.RequestConfig) {
+		cfg.RequestTimeout = d
+	}
+}
+
+// WithHeader adds an extra header to the outgoing request. Calling it
+// more than once with the same key appends rather than replaces.
+func WithHeader(key, value string) RequestOption {
+	return func(cfg *core.RequestConfig) {
+		cfg.Headers.Add(key, value)
+	}
+}
+
+// WithRetryPolicy overrides core.DefaultRetryPolicy for a single call.
+func WithRetryPolicy(p core.RetryPolicy) RequestOption {
+	return func(cfg *core.RequestConfig) {
+		cfg.RetryPolicy = p
+	}
+}
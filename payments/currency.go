@@ -0,0 +1,69 @@
+package payments
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// Currency is a cryptocurrency ticker as used in pay_currency and
+// payout_currency fields, e.g. "btc" or "usdttrc20". Unlike Status, the
+// set of currencies NOWPayments supports numbers in the hundreds and
+// changes over time, so Currency is not a closed enum: unrecognized
+// values decode to their own lower-cased ticker rather than collapsing
+// to a shared "unknown" value, only logged via UnknownValueLogger for
+// visibility.
+type Currency string
+
+// A handful of commonly used currencies, for callers that want a named
+// constant instead of a string literal. This is not an exhaustive list;
+// any other ticker NOWPayments accepts is a valid Currency value too.
+const (
+	CurrencyBTC  Currency = "btc"
+	CurrencyETH  Currency = "eth"
+	CurrencyLTC  Currency = "ltc"
+	CurrencyXRP  Currency = "xrp"
+	CurrencyUSDT Currency = "usdt"
+	CurrencyUSDC Currency = "usdc"
+)
+
+var knownCurrencies = map[Currency]bool{
+	CurrencyBTC:  true,
+	CurrencyETH:  true,
+	CurrencyLTC:  true,
+	CurrencyXRP:  true,
+	CurrencyUSDT: true,
+	CurrencyUSDC: true,
+}
+
+// String implements fmt.Stringer.
+func (c Currency) String() string {
+	return string(c)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Any value decodes, but
+// ones outside the named constants are reported to UnknownValueLogger
+// so operators can track how often they show up.
+//
+// Currency backs both pay_currency and payout_currency fields, and
+// UnmarshalJSON has no way to know which one it was called for, so the
+// field name passed to UnknownValueLogger is just "currency" rather than
+// guessing a specific JSON field.
+func (c *Currency) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return eris.Wrap(err, "unmarshal currency")
+	}
+	v := Currency(strings.ToLower(raw))
+	if !knownCurrencies[v] {
+		UnknownValueLogger("currency", raw)
+	}
+	*c = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Currency) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(c))
+}
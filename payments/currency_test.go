@@ -0,0 +1,50 @@
+package payments
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCurrencyUnmarshalJSON(t *testing.T) {
+	var c Currency
+	if err := json.Unmarshal([]byte(`"BTC"`), &c); err != nil {
+		t.Fatalf("unmarshal known currency: %v", err)
+	}
+	if c != CurrencyBTC {
+		t.Fatalf("c = %q, want %q", c, CurrencyBTC)
+	}
+}
+
+func TestCurrencyUnmarshalJSONUnknownLogsGenericFieldName(t *testing.T) {
+	old := UnknownValueLogger
+	defer func() { UnknownValueLogger = old }()
+
+	var gotField, gotValue string
+	UnknownValueLogger = func(field, value string) {
+		gotField, gotValue = field, value
+	}
+
+	var c Currency
+	if err := json.Unmarshal([]byte(`"dogecoin"`), &c); err != nil {
+		t.Fatalf("unmarshal unknown currency: %v", err)
+	}
+	if c != Currency("dogecoin") {
+		t.Fatalf("c = %q, want %q", c, "dogecoin")
+	}
+	if gotField != "currency" {
+		t.Fatalf("logged field = %q, want %q; Currency backs both pay_currency and payout_currency so it can't assert a specific one", gotField, "currency")
+	}
+	if gotValue != "dogecoin" {
+		t.Fatalf("logged value = %q, want %q", gotValue, "dogecoin")
+	}
+}
+
+func TestCurrencyMarshalJSON(t *testing.T) {
+	b, err := json.Marshal(CurrencyETH)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(b) != `"eth"` {
+		t.Fatalf("marshal = %s, want %q", b, `"eth"`)
+	}
+}
@@ -0,0 +1,126 @@
+package payments
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/topkekega/go-nowpayments/core"
+	"github.com/topkekega/go-nowpayments/option"
+)
+
+// ListParams filters and pages through payments.List.
+type ListParams struct {
+	// Status filters to payments currently in this payment_status.
+	Status string
+	// Currency filters to payments paid in this pay_currency.
+	Currency string
+	// SortOrder is "asc" or "desc"; NOWPayments sorts by creation date.
+	SortOrder string
+	// DateFrom and DateTo filter by creation date, in the format the
+	// NOWPayments API expects (RFC 3339).
+	DateFrom string
+	DateTo   string
+	// Limit caps the number of payments fetched per page. Defaults to
+	// 100 if <= 0.
+	Limit int
+}
+
+func (p ListParams) query(page int) map[string]string {
+	limit := p.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	q := map[string]string{
+		"limit": strconv.Itoa(limit),
+		"page":  strconv.Itoa(page),
+	}
+	if p.Status != "" {
+		q["status"] = p.Status
+	}
+	if p.Currency != "" {
+		q["currency"] = p.Currency
+	}
+	if p.SortOrder != "" {
+		q["sortOrder"] = p.SortOrder
+	}
+	if p.DateFrom != "" {
+		q["dateFrom"] = p.DateFrom
+	}
+	if p.DateTo != "" {
+		q["dateTo"] = p.DateTo
+	}
+	return q
+}
+
+// listResponse mirrors the NOWPayments list-payments response envelope.
+type listResponse struct {
+	Data  []Payment `json:"data"`
+	Total int       `json:"total"`
+}
+
+// List returns an iterator over payments matching params. Pages are
+// fetched lazily, as Next is called, so callers can stream through
+// large result sets without loading everything into memory.
+func List(ctx context.Context, params ListParams, opts ...option.RequestOption) *Iter {
+	return &Iter{ctx: ctx, params: params, opts: opts}
+}
+
+// Iter auto-paginates through a payments.List call. Call Next before
+// every Payment, and check Err once Next returns false.
+type Iter struct {
+	ctx    context.Context
+	params ListParams
+	opts   []option.RequestOption
+
+	page     []Payment
+	idx      int
+	current  *Payment
+	nextPage int
+	done     bool
+	err      error
+}
+
+// Next advances the iterator, fetching the next page from the API if
+// the current one is exhausted. It returns false once there are no more
+// payments or an error occurred; check Err to tell the two apart.
+func (it *Iter) Next() bool {
+	if it.err != nil || it.done {
+		return false
+	}
+	if it.idx >= len(it.page) {
+		if err := it.fetchPage(); err != nil {
+			it.err = err
+			return false
+		}
+		if len(it.page) == 0 {
+			it.done = true
+			return false
+		}
+	}
+	it.current = &it.page[it.idx]
+	it.idx++
+	return true
+}
+
+// Payment returns the payment at the iterator's current position. It is
+// only valid after a call to Next that returned true.
+func (it *Iter) Payment() *Payment { return it.current }
+
+// Err returns the first error encountered while fetching pages, if any.
+func (it *Iter) Err() error { return it.err }
+
+func (it *Iter) fetchPage() error {
+	var resp listResponse
+	par := &core.SendParams{
+		RouteName: core.RoutePaymentList,
+		Into:      &resp,
+		Query:     it.params.query(it.nextPage),
+	}
+	if err := core.HTTPSend(it.ctx, par, it.opts...); err != nil {
+		return err
+	}
+	it.page = resp.Data
+	it.idx = 0
+	it.nextPage++
+	return nil
+}
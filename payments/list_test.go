@@ -0,0 +1,114 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/topkekega/go-nowpayments/core"
+	"github.com/topkekega/go-nowpayments/option"
+)
+
+func TestIterPaginatesAcrossPages(t *testing.T) {
+	pages := [][]Payment{
+		{{ID: "1"}, {ID: "2"}},
+		{{ID: "3"}},
+		{},
+	}
+	var gotPages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		gotPages = append(gotPages, page)
+		idx := len(gotPages) - 1
+		_ = json.NewEncoder(w).Encode(listResponse{Data: pages[idx]})
+	}))
+	defer srv.Close()
+
+	it := List(context.Background(), ListParams{}, option.WithBaseURL(srv.URL))
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Payment().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	want := []string{"1", "2", "3"}
+	if len(ids) != len(want) {
+		t.Fatalf("ids = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("ids = %v, want %v", ids, want)
+		}
+	}
+	if len(gotPages) != 3 {
+		t.Fatalf("fetched %d pages, want 3 (stop once a page comes back empty)", len(gotPages))
+	}
+}
+
+func TestIterEmptyFirstPage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(listResponse{Data: nil})
+	}))
+	defer srv.Close()
+
+	it := List(context.Background(), ListParams{}, option.WithBaseURL(srv.URL))
+	if it.Next() {
+		t.Fatal("Next() = true on an empty first page, want false")
+	}
+	if it.Err() != nil {
+		t.Fatalf("Err: %v", it.Err())
+	}
+}
+
+func TestIterStopsOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	it := List(context.Background(), ListParams{},
+		option.WithBaseURL(srv.URL),
+		option.WithRetryPolicy(core.RetryPolicy{MaxAttempts: 1}))
+	if it.Next() {
+		t.Fatal("Next() = true after a failed fetch, want false")
+	}
+	if it.Err() == nil {
+		t.Fatal("Err() = nil after a failed fetch, want an error")
+	}
+}
+
+func TestListParamsQueryDefaults(t *testing.T) {
+	p := ListParams{}
+	q := p.query(2)
+	if q["limit"] != "100" {
+		t.Fatalf("limit = %q, want %q", q["limit"], "100")
+	}
+	if q["page"] != "2" {
+		t.Fatalf("page = %q, want %q", q["page"], "2")
+	}
+	if _, ok := q["status"]; ok {
+		t.Fatal("query included status when ListParams.Status was empty")
+	}
+}
+
+func TestListParamsQueryFilters(t *testing.T) {
+	p := ListParams{Status: "finished", Currency: "btc", SortOrder: "asc", DateFrom: "a", DateTo: "b", Limit: 10}
+	q := p.query(0)
+	want := map[string]string{
+		"limit":     "10",
+		"page":      "0",
+		"status":    "finished",
+		"currency":  "btc",
+		"sortOrder": "asc",
+		"dateFrom":  "a",
+		"dateTo":    "b",
+	}
+	for k, v := range want {
+		if q[k] != v {
+			t.Fatalf("query[%q] = %q, want %q", k, q[k], v)
+		}
+	}
+}
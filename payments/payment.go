@@ -1,25 +1,30 @@
 package payments
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/matm/go-nowpayments/core"
 	"github.com/rotisserie/eris"
+	"github.com/topkekega/go-nowpayments/core"
+	"github.com/topkekega/go-nowpayments/option"
 )
 
 // PaymentAmount defines common fields used in PaymentArgs and
 // Payment structs.
 type PaymentAmount struct {
-	PriceAmount      float64 `json:"price_amount"`
-	PriceCurrency    string  `json:"price_currency"`
-	PayCurrency      string  `json:"pay_currency,omitempty"`
-	CallbackURL      string  `json:"ipn_callback_url,omitempty"`
-	OrderID          string  `json:"order_id,omitempty"`
-	OrderDescription string  `json:"order_description,omitempty"`
+	PriceAmount      float64  `json:"price_amount"`
+	PriceCurrency    string   `json:"price_currency"`
+	PayCurrency      Currency `json:"pay_currency,omitempty"`
+	CallbackURL      string   `json:"ipn_callback_url,omitempty"`
+	OrderID          string   `json:"order_id,omitempty"`
+	OrderDescription string   `json:"order_description,omitempty"`
 }
 
 // PaymentArgs are the arguments used to make a payment.
@@ -39,7 +44,7 @@ type PaymentArgs struct {
 	// in price_amount.
 	PayAmount float64 `json:"pay_amount,omitempty"`
 	// PayoutCurrency for the cryptocurrency name.
-	PayoutCurrency string `json:"payout_currency,omitempty"`
+	PayoutCurrency Currency `json:"payout_currency,omitempty"`
 	// PayoutExtraID is optional, extra id or memo or tag for external payout_address.
 	PayoutExtraID string `json:"payout_extra_id,omitempty"`
 	// PurchaseID is optional, id of purchase for which you want to create another
@@ -59,22 +64,22 @@ type PaymentArgs struct {
 type Payment struct {
 	PaymentAmount
 
-	ID                     string  `json:"payment_id"`
-	AmountReceived         float64 `json:"amount_received"`
-	BurningPercent         int     `json:"burning_percent"`
-	CreatedAt              string  `json:"created_at"`
-	ExpirationEstimateDate string  `json:"expiration_estimate_date"`
-	Network                string  `json:"network"`
-	NetworkPrecision       int     `json:"network_precision"`
-	PayAddress             string  `json:"pay_address"`
-	PayAmount              float64 `json:"pay_amount"`
-	PayCurrency            string  `json:"pay_currency"`
-	PayinExtraID           string  `json:"payin_extra_id"`
-	PurchaseID             string  `json:"purchase_id"`
-	SmartContract          string  `json:"smart_contract"`
-	Status                 string  `json:"payment_status"`
-	TimeLimit              string  `json:"time_limit"`
-	UpdatedAt              string  `json:"updated_at"`
+	ID                     string   `json:"payment_id"`
+	AmountReceived         float64  `json:"amount_received"`
+	BurningPercent         int      `json:"burning_percent"`
+	CreatedAt              string   `json:"created_at"`
+	ExpirationEstimateDate string   `json:"expiration_estimate_date"`
+	Network                string   `json:"network"`
+	NetworkPrecision       int      `json:"network_precision"`
+	PayAddress             string   `json:"pay_address"`
+	PayAmount              float64  `json:"pay_amount"`
+	PayCurrency            Currency `json:"pay_currency"`
+	PayinExtraID           string   `json:"payin_extra_id"`
+	PurchaseID             string   `json:"purchase_id"`
+	SmartContract          string   `json:"smart_contract"`
+	Status                 Status   `json:"payment_status"`
+	TimeLimit              string   `json:"time_limit"`
+	UpdatedAt              string   `json:"updated_at"`
 }
 
 // UnmarshalJSON provides custom unmarshalling to the Payment struct so it
@@ -95,11 +100,11 @@ func (p *Payment) UnmarshalJSON(b []byte) error {
 		NetworkPrecision       int         `json:"network_precision"`
 		PayAddress             string      `json:"pay_address"`
 		PayAmount              interface{} `json:"pay_amount"`
-		PayCurrency            string      `json:"pay_currency"`
+		PayCurrency            Currency    `json:"pay_currency"`
 		PayinExtraID           string      `json:"payin_extra_id"`
 		PurchaseID             string      `json:"purchase_id"`
 		SmartContract          string      `json:"smart_contract"`
-		Status                 string      `json:"payment_status"`
+		Status                 Status      `json:"payment_status"`
 		TimeLimit              string      `json:"time_limit"`
 		UpdatedAt              string      `json:"updated_at"`
 	}
@@ -151,8 +156,15 @@ func (p *Payment) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-// New creates a payment.
-func New(pa *PaymentArgs) (*Payment, error) {
+// NewContext creates a payment. ctx governs cancellation and deadlines
+// for the underlying HTTP round trip.
+//
+// Payment creation is not idempotent on the server side, so NewContext
+// always sends an Idempotency-Key header: a random one unless opts
+// supplies option.WithIdempotencyKey, in which case that value wins.
+// Retries performed internally by core.HTTPSend reuse the same key, so
+// a dropped response can never result in a duplicate charge.
+func NewContext(ctx context.Context, pa *PaymentArgs, opts ...option.RequestOption) (*Payment, error) {
 	if pa == nil {
 		return nil, errors.New("nil payment args")
 	}
@@ -162,31 +174,42 @@ func New(pa *PaymentArgs) (*Payment, error) {
 	}
 	p := &Payment{}
 	par := &core.SendParams{
-		RouteName: "payment-create",
+		RouteName: core.RoutePaymentCreate,
 		Into:      &p,
 		Body:      strings.NewReader(string(d)),
 	}
-	err = core.HTTPSend(par)
-	if err != nil {
+	opts = append([]option.RequestOption{option.WithIdempotencyKey(newIdempotencyKey())}, opts...)
+	if err := core.HTTPSend(ctx, par, opts...); err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
+// New is NewContext without a context, calling it with
+// context.Background(). This is the pre-context signature, kept as a
+// thin shim for source compatibility.
+//
+// Deprecated: use NewContext instead; this shim will be removed in a
+// future release.
+func New(pa *PaymentArgs) (*Payment, error) {
+	return NewContext(context.Background(), pa)
+}
+
 type InvoicePaymentArgs struct {
-	InvoiceID        string `json:"iid"`
-	PayCurrency      string `json:"pay_currency"`
-	PurchaseID       string `json:"purchase_id,omitempty"`
-	OrderDescription string `json:"order_description,omitempty"`
-	CustomerEmail    string `json:"customer_email,omitempty"`
-	PayoutCurrency   string `json:"payout_currency,omitempty"`
-	PayoutExtraID    string `json:"payout_extra_id,omitempty"`
-	PayoutAddress    string `json:"payout_address,omitempty"`
+	InvoiceID        string   `json:"iid"`
+	PayCurrency      Currency `json:"pay_currency"`
+	PurchaseID       string   `json:"purchase_id,omitempty"`
+	OrderDescription string   `json:"order_description,omitempty"`
+	CustomerEmail    string   `json:"customer_email,omitempty"`
+	PayoutCurrency   Currency `json:"payout_currency,omitempty"`
+	PayoutExtraID    string   `json:"payout_extra_id,omitempty"`
+	PayoutAddress    string   `json:"payout_address,omitempty"`
 }
 
-// NewFromInvoice creates a payment from an existing invoice. ID is the
-// invoice's identifier.
-func NewFromInvoice(ipa *InvoicePaymentArgs) (*Payment, error) {
+// NewFromInvoiceContext creates a payment from an existing invoice.
+// ipa.InvoiceID is the invoice's identifier. ctx governs cancellation and deadlines
+// for the underlying HTTP round trip.
+func NewFromInvoiceContext(ctx context.Context, ipa *InvoicePaymentArgs, opts ...option.RequestOption) (*Payment, error) {
 	if ipa == nil {
 		return nil, errors.New("nil invoice payment args")
 	}
@@ -196,13 +219,43 @@ func NewFromInvoice(ipa *InvoicePaymentArgs) (*Payment, error) {
 	}
 	p := &Payment{}
 	par := &core.SendParams{
-		RouteName: "invoice-payment",
+		RouteName: core.RouteInvoicePayment,
 		Into:      &p,
 		Body:      strings.NewReader(string(d)),
 	}
-	err = core.HTTPSend(par)
-	if err != nil {
+	opts = append([]option.RequestOption{option.WithIdempotencyKey(newIdempotencyKey())}, opts...)
+	if err := core.HTTPSend(ctx, par, opts...); err != nil {
 		return nil, err
 	}
 	return p, nil
 }
+
+// fallbackKeyCounter disambiguates newIdempotencyKey's fallback path
+// across calls within a process, since the whole point of an
+// idempotency key is that it not collide with any other call's.
+var fallbackKeyCounter uint64
+
+// newIdempotencyKey returns a random 128-bit hex-encoded key suitable
+// for the Idempotency-Key header.
+func newIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand failing is effectively unrecoverable; fall back to
+		// a value that's merely unique, not random, rather than
+		// panicking. It must still vary per call: two payments created
+		// during the same rand failure must never share a key.
+		n := atomic.AddUint64(&fallbackKeyCounter, 1)
+		return fmt.Sprintf("fallback-%d-%d", time.Now().UnixNano(), n)
+	}
+	return fmt.Sprintf("%x", b)
+}
+
+// NewFromInvoice is NewFromInvoiceContext without a context, calling it
+// with context.Background(). This is the pre-context signature, kept as
+// a thin shim for source compatibility.
+//
+// Deprecated: use NewFromInvoiceContext instead; this shim will be
+// removed in a future release.
+func NewFromInvoice(ipa *InvoicePaymentArgs) (*Payment, error) {
+	return NewFromInvoiceContext(context.Background(), ipa)
+}
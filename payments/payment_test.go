@@ -0,0 +1,62 @@
+package payments
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+// failingReader always fails, simulating crypto/rand.Read returning an
+// error so newIdempotencyKey falls back.
+type failingReader struct{}
+
+func (failingReader) Read(b []byte) (int, error) {
+	return 0, errors.New("rand unavailable")
+}
+
+func TestNewIdempotencyKeyFallbackIsUnique(t *testing.T) {
+	old := rand.Reader
+	rand.Reader = failingReader{}
+	defer func() { rand.Reader = old }()
+
+	keys := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		k := newIdempotencyKey()
+		if keys[k] {
+			t.Fatalf("newIdempotencyKey returned a repeated fallback key %q on call %d; every payment created during a rand failure would get the same idempotency key", k, i)
+		}
+		keys[k] = true
+	}
+}
+
+func TestNewIdempotencyKeyRandomPath(t *testing.T) {
+	a := newIdempotencyKey()
+	b := newIdempotencyKey()
+	if a == b {
+		t.Fatalf("newIdempotencyKey returned the same key twice: %q", a)
+	}
+	if len(a) != 32 {
+		t.Fatalf("len(newIdempotencyKey()) = %d, want 32 (16 bytes hex-encoded)", len(a))
+	}
+}
+
+var _ io.Reader = failingReader{}
+
+// TestNewIsAThinShim exercises the pre-context New without making an
+// HTTP call, by relying on the nil-args check NewContext performs
+// before ever touching the network. It confirms New forwards to
+// NewContext rather than having drifted into its own implementation.
+func TestNewIsAThinShim(t *testing.T) {
+	_, err := New(nil)
+	if err == nil || err.Error() != "nil payment args" {
+		t.Fatalf("New(nil) = %v, want the same \"nil payment args\" error NewContext returns", err)
+	}
+}
+
+func TestNewFromInvoiceIsAThinShim(t *testing.T) {
+	_, err := NewFromInvoice(nil)
+	if err == nil || err.Error() != "nil invoice payment args" {
+		t.Fatalf("NewFromInvoice(nil) = %v, want the same \"nil invoice payment args\" error NewFromInvoiceContext returns", err)
+	}
+}
@@ -0,0 +1,93 @@
+package payments
+
+import (
+	"encoding/json"
+
+	"github.com/rotisserie/eris"
+)
+
+// Status is a Payment's payment_status, as reported by the NOWPayments
+// API.
+type Status string
+
+// Known payment statuses, roughly in the order a payment moves through
+// them: waiting -> confirming -> confirmed -> sending -> finished.
+const (
+	StatusWaiting       Status = "waiting"
+	StatusConfirming    Status = "confirming"
+	StatusConfirmed     Status = "confirmed"
+	StatusSending       Status = "sending"
+	StatusPartiallyPaid Status = "partially_paid"
+	StatusFinished      Status = "finished"
+	StatusFailed        Status = "failed"
+	StatusRefunded      Status = "refunded"
+	StatusExpired       Status = "expired"
+
+	// StatusUnknown is substituted for any payment_status value this
+	// version of the package doesn't recognize, so a new status added to
+	// the API doesn't break decoding of everything else in the response.
+	StatusUnknown Status = ""
+)
+
+var knownStatuses = map[Status]bool{
+	StatusWaiting:       true,
+	StatusConfirming:    true,
+	StatusConfirmed:     true,
+	StatusSending:       true,
+	StatusPartiallyPaid: true,
+	StatusFinished:      true,
+	StatusFailed:        true,
+	StatusRefunded:      true,
+	StatusExpired:       true,
+}
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	if s == StatusUnknown {
+		return "unknown"
+	}
+	return string(s)
+}
+
+// IsTerminal reports whether s is a status a payment will never move on
+// from, so a polling loop knows when to stop.
+func (s Status) IsTerminal() bool {
+	switch s {
+	case StatusFinished, StatusFailed, StatusRefunded, StatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnknownValueLogger is called whenever decoding encounters a
+// payment_status or currency value this package doesn't recognize, so
+// operators can notice new API values instead of having them silently
+// coerced. field is "payment_status" or "currency"; Currency backs both
+// pay_currency and payout_currency, so it can't report which one a
+// given call came from. It defaults to a no-op; set it once at program
+// start if you want the visibility.
+var UnknownValueLogger = func(field, value string) {}
+
+// UnmarshalJSON implements json.Unmarshaler. Unrecognized values decode
+// to StatusUnknown rather than failing, so forward-compatible API
+// additions don't break existing callers.
+func (s *Status) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return eris.Wrap(err, "unmarshal payment status")
+	}
+	v := Status(raw)
+	if !knownStatuses[v] {
+		UnknownValueLogger("payment_status", raw)
+		*s = StatusUnknown
+		return nil
+	}
+	*s = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(s))
+}
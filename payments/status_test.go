@@ -0,0 +1,42 @@
+package payments
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStatusUnmarshalJSONUnknownBecomesStatusUnknown(t *testing.T) {
+	old := UnknownValueLogger
+	defer func() { UnknownValueLogger = old }()
+
+	var gotField, gotValue string
+	UnknownValueLogger = func(field, value string) {
+		gotField, gotValue = field, value
+	}
+
+	var s Status
+	if err := json.Unmarshal([]byte(`"brand_new_status"`), &s); err != nil {
+		t.Fatalf("unmarshal unknown status: %v", err)
+	}
+	if s != StatusUnknown {
+		t.Fatalf("s = %q, want StatusUnknown", s)
+	}
+	if gotField != "payment_status" || gotValue != "brand_new_status" {
+		t.Fatalf("logged (%q, %q), want (%q, %q)", gotField, gotValue, "payment_status", "brand_new_status")
+	}
+}
+
+func TestStatusIsTerminal(t *testing.T) {
+	terminal := []Status{StatusFinished, StatusFailed, StatusRefunded, StatusExpired}
+	for _, s := range terminal {
+		if !s.IsTerminal() {
+			t.Errorf("%q.IsTerminal() = false, want true", s)
+		}
+	}
+	nonTerminal := []Status{StatusWaiting, StatusConfirming, StatusConfirmed, StatusSending, StatusPartiallyPaid, StatusUnknown}
+	for _, s := range nonTerminal {
+		if s.IsTerminal() {
+			t.Errorf("%q.IsTerminal() = true, want false", s)
+		}
+	}
+}
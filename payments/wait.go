@@ -0,0 +1,109 @@
+package payments
+
+import (
+	"context"
+	"time"
+
+	"github.com/topkekega/go-nowpayments/core"
+	"github.com/topkekega/go-nowpayments/option"
+)
+
+// defaultPollInterval and defaultMaxPollInterval are WaitUntilTerminal's
+// interval defaults.
+const (
+	defaultPollInterval    = 15 * time.Second
+	defaultMaxPollInterval = 2 * time.Minute
+)
+
+// Get fetches the current state of the payment identified by id.
+func Get(ctx context.Context, id string, opts ...option.RequestOption) (*Payment, error) {
+	p := &Payment{}
+	par := &core.SendParams{
+		RouteName: core.RoutePaymentStatus,
+		Into:      &p,
+		PathArgs:  []interface{}{id},
+	}
+	if err := core.HTTPSend(ctx, par, opts...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// waitConfig holds WaitUntilTerminal's tunables, assembled from the
+// WaitOption values passed to it.
+type waitConfig struct {
+	pollInterval time.Duration
+	maxInterval  time.Duration
+	onUpdate     func(*Payment)
+	reqOpts      []option.RequestOption
+}
+
+// WaitOption configures WaitUntilTerminal.
+type WaitOption func(*waitConfig)
+
+// WithPollInterval sets the initial delay between polls. Defaults to 15s.
+func WithPollInterval(d time.Duration) WaitOption {
+	return func(c *waitConfig) { c.pollInterval = d }
+}
+
+// WithMaxInterval caps how far the poll interval is allowed to back off
+// to. Defaults to 2 minutes.
+func WithMaxInterval(d time.Duration) WaitOption {
+	return func(c *waitConfig) { c.maxInterval = d }
+}
+
+// WithOnUpdate registers fn to be called with every payment fetched
+// while waiting, including the final terminal one, so callers can drive
+// UIs or logs as the payment moves through
+// waiting -> confirming -> confirmed -> sending -> finished.
+func WithOnUpdate(fn func(*Payment)) WaitOption {
+	return func(c *waitConfig) { c.onUpdate = fn }
+}
+
+// WithRequestOptions forwards opts to every Get call WaitUntilTerminal
+// issues while polling. There's no persistent client config in this
+// package, so without this, a polling loop has no way to supply an API
+// key, sandbox base URL, or custom HTTP client.
+func WithRequestOptions(opts ...option.RequestOption) WaitOption {
+	return func(c *waitConfig) { c.reqOpts = append(c.reqOpts, opts...) }
+}
+
+// WaitUntilTerminal polls Get(ctx, id) until the payment's
+// Status.IsTerminal() is true or ctx is cancelled, whichever comes
+// first. The poll interval starts at WithPollInterval and doubles after
+// every poll, up to WithMaxInterval, so a long-pending payment doesn't
+// hammer the API. Pass WithRequestOptions to supply an API key or other
+// per-call option to the polling requests.
+func WaitUntilTerminal(ctx context.Context, id string, opts ...WaitOption) (*Payment, error) {
+	cfg := &waitConfig{
+		pollInterval: defaultPollInterval,
+		maxInterval:  defaultMaxPollInterval,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	interval := cfg.pollInterval
+	for {
+		p, err := Get(ctx, id, cfg.reqOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.onUpdate != nil {
+			cfg.onUpdate(p)
+		}
+		if p.Status.IsTerminal() {
+			return p, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+		if interval > cfg.maxInterval {
+			interval = cfg.maxInterval
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/topkekega/go-nowpayments/option"
+)
+
+func TestWaitUntilTerminalForwardsRequestOptions(t *testing.T) {
+	var gotAPIKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAPIKey = r.Header.Get("x-api-key")
+		_ = json.NewEncoder(w).Encode(Payment{Status: StatusFinished})
+	}))
+	defer srv.Close()
+
+	p, err := WaitUntilTerminal(context.Background(), "123",
+		WithRequestOptions(option.WithBaseURL(srv.URL), option.WithAPIKey("test-key")))
+	if err != nil {
+		t.Fatalf("WaitUntilTerminal: %v", err)
+	}
+	if p.Status != StatusFinished {
+		t.Fatalf("Status = %q, want %q", p.Status, StatusFinished)
+	}
+	if gotAPIKey != "test-key" {
+		t.Fatalf("x-api-key header = %q, want %q; WithRequestOptions wasn't forwarded to Get", gotAPIKey, "test-key")
+	}
+}
+
+func TestWaitUntilTerminalPollsUntilTerminal(t *testing.T) {
+	statuses := []Status{StatusWaiting, StatusConfirming, StatusFinished}
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := statuses[calls]
+		calls++
+		_ = json.NewEncoder(w).Encode(Payment{Status: s})
+	}))
+	defer srv.Close()
+
+	var updates []Status
+	p, err := WaitUntilTerminal(context.Background(), "123",
+		WithRequestOptions(option.WithBaseURL(srv.URL)),
+		WithPollInterval(time.Millisecond),
+		WithMaxInterval(5*time.Millisecond),
+		WithOnUpdate(func(p *Payment) { updates = append(updates, p.Status) }))
+	if err != nil {
+		t.Fatalf("WaitUntilTerminal: %v", err)
+	}
+	if p.Status != StatusFinished {
+		t.Fatalf("Status = %q, want %q", p.Status, StatusFinished)
+	}
+	if calls != len(statuses) {
+		t.Fatalf("calls = %d, want %d", calls, len(statuses))
+	}
+	if len(updates) != len(statuses) {
+		t.Fatalf("onUpdate ran %d times, want %d", len(updates), len(statuses))
+	}
+}
+
+func TestWaitUntilTerminalRespectsContextCancellation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Payment{Status: StatusWaiting})
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := WaitUntilTerminal(ctx, "123",
+		WithRequestOptions(option.WithBaseURL(srv.URL)),
+		WithPollInterval(time.Hour))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}